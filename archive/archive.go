@@ -0,0 +1,13 @@
+// Package archive writes the contents of an fs.FS into tar or zip archives,
+// honoring whatever ModTime and Mode metadata the FS reports - for example
+// one wrapped with wrapfs.WithModTime or wrapfs.WithFileMode.
+package archive
+
+// Readlinker may be implemented by an fs.FileInfo to expose the target of a
+// symbolic link. fs.FS has no standard way of reading a link target, so
+// WriteTar and WriteZip skip symlinks unless their fs.FileInfo implements
+// this interface.
+type Readlinker interface {
+	// Readlink returns the target the symbolic link points to.
+	Readlink() (string, error)
+}
@@ -0,0 +1,123 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"embed"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/wrapfs"
+	"github.com/ironsmile/wrapfs/archive"
+)
+
+//go:embed testdata
+var testFS embed.FS
+
+// TestWriteTarHonorsModTime makes sure that WriteTar uses the ModTime
+// reported by the wrapped fs.FS, not the one of the files on disk.
+func TestWriteTarHonorsModTime(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Unix(1727600261, 0)
+	fsys := wrapfs.WithModTime(testFS, modTime)
+
+	var buf bytes.Buffer
+	if err := archive.WriteTar(&buf, fsys); err != nil {
+		t.Fatalf("WriteTar returned an error: %s\n", err)
+	}
+
+	tr := tar.NewReader(&buf)
+	found := false
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar.Reader.Next returned an error: %s\n", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		found = true
+		if !header.ModTime.Equal(modTime) {
+			t.Errorf("[%s] expected mod time %s but got %s", header.Name, modTime, header.ModTime)
+		}
+	}
+
+	if !found {
+		t.Fatalf("expected at least one regular file in the archive")
+	}
+}
+
+// TestWriteTarHonorsMode makes sure that WriteTar uses the permission bits
+// reported by the wrapped fs.FS.
+func TestWriteTarHonorsMode(t *testing.T) {
+	t.Parallel()
+
+	fsys := wrapfs.WithFileMode(testFS, 0o644)
+
+	var buf bytes.Buffer
+	if err := archive.WriteTar(&buf, fsys); err != nil {
+		t.Fatalf("WriteTar returned an error: %s\n", err)
+	}
+
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar.Reader.Next returned an error: %s\n", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if header.Mode != 0o644 {
+			t.Errorf("[%s] expected mode %o but got %o", header.Name, 0o644, header.Mode)
+		}
+	}
+}
+
+// TestWriteZipHonorsModTime makes sure that WriteZip uses the ModTime
+// reported by the wrapped fs.FS, not the one of the files on disk.
+func TestWriteZipHonorsModTime(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Unix(1727600261, 0)
+	fsys := wrapfs.WithModTime(testFS, modTime)
+
+	var buf bytes.Buffer
+	if err := archive.WriteZip(&buf, fsys); err != nil {
+		t.Fatalf("WriteZip returned an error: %s\n", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader returned an error: %s\n", err)
+	}
+
+	found := false
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		found = true
+		if !f.Modified.Equal(modTime) {
+			t.Errorf("[%s] expected mod time %s but got %s", f.Name, modTime, f.Modified)
+		}
+	}
+
+	if !found {
+		t.Fatalf("expected at least one regular file in the archive")
+	}
+}
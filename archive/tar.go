@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// WriteTar walks fsys and writes its contents to w as a tar archive. The
+// tar header of each entry is built from the fs.FileInfo reported by fsys,
+// so ModTime and Mode - including those overridden by a wrapfs wrapper -
+// are carried over into the archive.
+//
+// Symbolic links are skipped unless their fs.FileInfo implements Readlinker.
+func WriteTar(w io.Writer, fsys fs.FS) error {
+	tw := tar.NewWriter(w)
+
+	if err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == "." {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", path, err)
+		}
+
+		header, err := tarHeader(path, info)
+		if err != nil {
+			return err
+		}
+		if header == nil {
+			return nil
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("write tar header for %s: %w", path, err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			return nil
+		}
+
+		f, err := fsys.Open(path)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", path, err)
+		}
+		defer f.Close()
+
+		if _, err := io.Copy(tw, f); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// tarHeader builds a *tar.Header for path out of info, following the same
+// fs.FileInfo -> tar.Header mapping used by the standard library's
+// archive/tar.FileInfoHeader, but deriving the type flag from
+// info.Mode()&fs.ModeType so that it keeps working for fs.FileInfo
+// implementations wrapped by this module. It returns a nil header for
+// entries which should be skipped, such as symlinks without a Readlinker.
+func tarHeader(path string, info fs.FileInfo) (*tar.Header, error) {
+	mode := info.Mode()
+
+	header := &tar.Header{
+		Name:    path,
+		ModTime: info.ModTime(),
+		Mode:    int64(mode.Perm()),
+	}
+
+	switch {
+	case mode.IsDir():
+		header.Typeflag = tar.TypeDir
+		header.Name += "/"
+	case mode&fs.ModeSymlink != 0:
+		rl, ok := info.(Readlinker)
+		if !ok {
+			return nil, nil
+		}
+
+		target, err := rl.Readlink()
+		if err != nil {
+			return nil, fmt.Errorf("readlink %s: %w", path, err)
+		}
+
+		header.Typeflag = tar.TypeSymlink
+		header.Linkname = target
+	case mode.IsRegular():
+		header.Typeflag = tar.TypeReg
+		header.Size = info.Size()
+	default:
+		// Devices, sockets, named pipes and the like have no place in an
+		// archive built from an fs.FS.
+		return nil, nil
+	}
+
+	return header, nil
+}
@@ -0,0 +1,99 @@
+package archive
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// WriteZip walks fsys and writes its contents to w as a zip archive. The
+// zip header of each entry is built from the fs.FileInfo reported by fsys,
+// so ModTime - including one overridden by a wrapfs wrapper - is carried
+// over into the archive. Mode is stored in the header's external attributes,
+// matching the behaviour of archive/zip.FileInfoHeader.
+//
+// Symbolic links are skipped unless their fs.FileInfo implements Readlinker.
+func WriteZip(w io.Writer, fsys fs.FS) error {
+	zw := zip.NewWriter(w)
+
+	if err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == "." {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", path, err)
+		}
+
+		mode := info.Mode()
+
+		if mode&fs.ModeSymlink != 0 {
+			rl, ok := info.(Readlinker)
+			if !ok {
+				return nil
+			}
+
+			target, err := rl.Readlink()
+			if err != nil {
+				return fmt.Errorf("readlink %s: %w", path, err)
+			}
+
+			header, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return fmt.Errorf("build zip header for %s: %w", path, err)
+			}
+			header.Name = path
+			header.Method = zip.Store
+
+			wr, err := zw.CreateHeader(header)
+			if err != nil {
+				return fmt.Errorf("create zip entry for %s: %w", path, err)
+			}
+
+			_, err = io.WriteString(wr, target)
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return fmt.Errorf("build zip header for %s: %w", path, err)
+		}
+		header.Name = path
+		if mode.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+
+		wr, err := zw.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("create zip entry for %s: %w", path, err)
+		}
+
+		if mode.IsDir() || !mode.IsRegular() {
+			return nil
+		}
+
+		f, err := fsys.Open(path)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", path, err)
+		}
+		defer f.Close()
+
+		if _, err := io.Copy(wr, f); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
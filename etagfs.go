@@ -0,0 +1,122 @@
+package wrapfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// ETager may be implemented by an opened fs.File to expose an ETag for its
+// content. wrapfs.FileServer uses it to answer requests with an ETag
+// response header and honor If-None-Match.
+type ETager interface {
+	// ETag returns the entity tag for the file's content, including the
+	// surrounding quotes expected in the ETag and If-None-Match headers.
+	ETag() string
+}
+
+// WithETag returns a fs.FS which wraps fsys so that every opened fs.File
+// also implements ETager, with fn computing the tag lazily from the file's
+// slash-separated path and its fs.FileInfo.
+func WithETag(fsys fs.FS, fn func(path string, info fs.FileInfo) string) fs.FS {
+	return &etagFS{
+		fsys: fsys,
+		fn:   fn,
+	}
+}
+
+// WithContentHashETag returns a fs.FS like WithETag, except the tag is the
+// sha256 hash of the file's content, computed lazily on first use of ETag()
+// and cached for the lifetime of the opened file.
+func WithContentHashETag(fsys fs.FS) fs.FS {
+	return &etagFS{
+		fsys: fsys,
+		fn:   nil,
+	}
+}
+
+type etagFS struct {
+	fsys fs.FS
+	// fn is nil when the ETag should instead be derived from the content
+	// hash of the opened file, as done by WithContentHashETag.
+	fn func(path string, info fs.FileInfo) string
+}
+
+func (e *etagFS) Open(name string) (fs.File, error) {
+	f, err := e.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &etagFile{File: f, path: name, fn: e.fn}, nil
+}
+
+type etagFile struct {
+	fs.File
+	path string
+	fn   func(path string, info fs.FileInfo) string
+
+	once sync.Once
+	etag string
+	err  error
+}
+
+// ReadDir implements fs.ReadDirFile for directories opened through an
+// etagFS, forwarding to the underlying fs.ReadDirFile. Directories have no
+// meaningful ETag, so they only need to keep being usable as directories.
+func (f *etagFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	rd, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: f.path, Err: fs.ErrInvalid}
+	}
+
+	return rd.ReadDir(n)
+}
+
+// ETag returns the file's entity tag, or the empty string for directories
+// and files whose tag could not be computed.
+func (f *etagFile) ETag() string {
+	f.once.Do(func() {
+		info, err := f.File.Stat()
+		if err != nil {
+			f.err = err
+			return
+		}
+
+		if info.IsDir() {
+			return
+		}
+
+		if f.fn != nil {
+			f.etag = f.fn(f.path, info)
+			return
+		}
+
+		f.etag, f.err = contentHashETag(f.File)
+	})
+
+	return f.etag
+}
+
+// contentHashETag computes a quoted, hex-encoded sha256 ETag from r. r must
+// support Seek, since the caller still needs to read the file content
+// afterwards.
+func contentHashETag(r fs.File) (string, error) {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return "", &fs.PathError{Op: "etag", Path: "", Err: fs.ErrInvalid}
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`, nil
+}
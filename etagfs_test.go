@@ -0,0 +1,191 @@
+package wrapfs_test
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/wrapfs"
+)
+
+// ExampleFileServer makes sure that a file served through an fs.FS wrapped
+// with WithETag gets an ETag response header.
+func ExampleFileServer() {
+	etagFS := wrapfs.WithETag(testFS, func(path string, info fs.FileInfo) string {
+		return `"fixed-etag"`
+	})
+
+	handler := wrapfs.FileServer(etagFS)
+	req := httptest.NewRequest(http.MethodGet, "/modtimefs.go", nil)
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+	defer resp.Result().Body.Close()
+
+	fmt.Printf("ETag header: %s\n", resp.Result().Header.Get("ETag"))
+	// Output: ETag header: "fixed-etag"
+}
+
+// ExampleFileServer_second makes sure that when If-None-Match matches the
+// served file's ETag, FileServer answers with 304.
+func ExampleFileServer_second() {
+	etagFS := wrapfs.WithETag(testFS, func(path string, info fs.FileInfo) string {
+		return `"fixed-etag"`
+	})
+
+	handler := wrapfs.FileServer(etagFS)
+	req := httptest.NewRequest(http.MethodGet, "/modtimefs.go", nil)
+	req.Header.Set("If-None-Match", `"fixed-etag"`)
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+	defer resp.Result().Body.Close()
+
+	fmt.Printf("HTTP Status Code: %d\n", resp.Result().StatusCode)
+	// Output: HTTP Status Code: 304
+}
+
+// TestWithContentHashETagStable checks that WithContentHashETag returns the
+// same tag across repeated opens of the same unchanged file.
+func TestWithContentHashETagStable(t *testing.T) {
+	t.Parallel()
+
+	etagFS := wrapfs.WithContentHashETag(testFS)
+
+	first, err := openETag(etagFS, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("first open returned an error: %s\n", err)
+	}
+
+	second, err := openETag(etagFS, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("second open returned an error: %s\n", err)
+	}
+
+	if first != second {
+		t.Errorf("expected stable ETag but got %q and %q", first, second)
+	}
+}
+
+// TestWithContentHashETagStillReadable checks that reading the ETag of a
+// file wrapped with WithContentHashETag does not consume its content.
+func TestWithContentHashETagStillReadable(t *testing.T) {
+	t.Parallel()
+
+	original, err := testFS.ReadFile("modtimefs.go")
+	if err != nil {
+		t.Fatalf("ReadFile returned an error: %s\n", err)
+	}
+
+	etagFS := wrapfs.WithContentHashETag(testFS)
+
+	fh, err := etagFS.Open("modtimefs.go")
+	if err != nil {
+		t.Fatalf("Open returned an error: %s\n", err)
+	}
+	defer fh.Close()
+
+	tagger, ok := fh.(wrapfs.ETager)
+	if !ok {
+		t.Fatalf("opened file does not implement ETager")
+	}
+
+	if tagger.ETag() == "" {
+		t.Fatalf("expected a non-empty ETag")
+	}
+
+	content := make([]byte, len(original))
+	if _, err := fh.Read(content); err != nil {
+		t.Fatalf("Read returned an error: %s\n", err)
+	}
+
+	if string(content) != string(original) {
+		t.Errorf("expected file content to be unaffected by reading the ETag")
+	}
+}
+
+// ExampleFileServer_third makes sure that a directory served through an
+// fs.FS wrapped with WithContentHashETag still lists its contents instead
+// of failing with a missing ReadDir method, and that it gets no bogus
+// empty ETag header.
+func ExampleFileServer_third() {
+	etagFS := wrapfs.WithContentHashETag(testFS)
+
+	handler := wrapfs.FileServer(etagFS)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+	result := resp.Result()
+	defer result.Body.Close()
+
+	fmt.Printf("HTTP Status Code: %d\n", result.StatusCode)
+	fmt.Printf("ETag header set: %t\n", result.Header.Get("ETag") != "")
+	// Output:
+	// HTTP Status Code: 200
+	// ETag header set: false
+}
+
+// TestWithContentHashETagDirReadDir checks that a directory opened through
+// an fs.FS wrapped with WithContentHashETag still implements
+// fs.ReadDirFile.
+func TestWithContentHashETagDirReadDir(t *testing.T) {
+	t.Parallel()
+
+	etagFS := wrapfs.WithContentHashETag(testFS)
+
+	fh, err := etagFS.Open(".")
+	if err != nil {
+		t.Fatalf("Open returned an error: %s\n", err)
+	}
+	defer fh.Close()
+
+	rd, ok := fh.(fs.ReadDirFile)
+	if !ok {
+		t.Fatalf("opened dir does not implement fs.ReadDirFile")
+	}
+
+	if _, err := rd.ReadDir(-1); err != nil {
+		t.Fatalf("ReadDir returned an error: %s\n", err)
+	}
+}
+
+// TestWithContentHashETagDirNoTag checks that ETag() is empty for a
+// directory instead of silently swallowing a read error.
+func TestWithContentHashETagDirNoTag(t *testing.T) {
+	t.Parallel()
+
+	etagFS := wrapfs.WithContentHashETag(testFS)
+
+	fh, err := etagFS.Open(".")
+	if err != nil {
+		t.Fatalf("Open returned an error: %s\n", err)
+	}
+	defer fh.Close()
+
+	tagger, ok := fh.(wrapfs.ETager)
+	if !ok {
+		t.Fatalf("opened dir does not implement ETager")
+	}
+
+	if tag := tagger.ETag(); tag != "" {
+		t.Errorf("expected no ETag for a directory but got %q", tag)
+	}
+}
+
+func openETag(fsys fs.FS, name string) (string, error) {
+	fh, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+
+	tagger, ok := fh.(wrapfs.ETager)
+	if !ok {
+		return "", fmt.Errorf("opened file does not implement ETager")
+	}
+
+	return tagger.ETag(), nil
+}
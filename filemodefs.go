@@ -0,0 +1,128 @@
+package wrapfs
+
+import "io/fs"
+
+// WithFileMode returns a fs.FS which wraps fsys and overrides the permission
+// bits reported by every file and directory in it with mode. The type bits
+// of the underlying fs.FileInfo (directory, symlink, etc.) are preserved -
+// only mode.Perm() is used. This is useful for making fs.FS implementations
+// which do not carry meaningful permissions, such as embed.FS, report
+// whatever permissions are needed by a consumer which re-serializes the
+// content, for example into a tar or zip archive.
+//
+// Use WithFileModeMask for finer control over which bits get overridden.
+func WithFileMode(fsys fs.FS, mode fs.FileMode) fs.FS {
+	return WithFileModeMask(fsys, mode, fs.ModePerm)
+}
+
+// WithFileModeMask returns a fs.FS which wraps fsys and overrides the bits
+// of every reported fs.FileMode selected by mask with the corresponding bits
+// from mode. Bits of the underlying mode which are not selected by mask,
+// such as the type bits, are preserved.
+func WithFileModeMask(fsys fs.FS, mode, mask fs.FileMode) fs.FS {
+	return &fileModeFS{
+		fsys: fsys,
+		mode: mode,
+		mask: mask,
+	}
+}
+
+type fileModeFS struct {
+	fsys fs.FS
+	mode fs.FileMode
+	mask fs.FileMode
+}
+
+func (m *fileModeFS) Open(name string) (fs.File, error) {
+	f, err := m.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileModeFile{File: f, mode: m.mode, mask: m.mask}, nil
+}
+
+func (m *fileModeFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(m.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileModeFileInfo{FileInfo: info, mode: m.mode, mask: m.mask}, nil
+}
+
+func (m *fileModeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(m.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := make([]fs.DirEntry, len(entries))
+	for i, entry := range entries {
+		wrapped[i] = &fileModeDirEntry{DirEntry: entry, mode: m.mode, mask: m.mask}
+	}
+
+	return wrapped, nil
+}
+
+type fileModeFile struct {
+	fs.File
+	mode fs.FileMode
+	mask fs.FileMode
+}
+
+func (f *fileModeFile) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileModeFileInfo{FileInfo: info, mode: f.mode, mask: f.mask}, nil
+}
+
+// ReadDir implements fs.ReadDirFile for directories opened through a
+// fileModeFS, so that entries read this way also report the overridden
+// mode.
+func (f *fileModeFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	rd, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: "", Err: fs.ErrInvalid}
+	}
+
+	entries, err := rd.ReadDir(n)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := make([]fs.DirEntry, len(entries))
+	for i, entry := range entries {
+		wrapped[i] = &fileModeDirEntry{DirEntry: entry, mode: f.mode, mask: f.mask}
+	}
+
+	return wrapped, nil
+}
+
+type fileModeFileInfo struct {
+	fs.FileInfo
+	mode fs.FileMode
+	mask fs.FileMode
+}
+
+func (fi *fileModeFileInfo) Mode() fs.FileMode {
+	return (fi.FileInfo.Mode() &^ fi.mask) | (fi.mode & fi.mask)
+}
+
+type fileModeDirEntry struct {
+	fs.DirEntry
+	mode fs.FileMode
+	mask fs.FileMode
+}
+
+func (e *fileModeDirEntry) Info() (fs.FileInfo, error) {
+	info, err := e.DirEntry.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileModeFileInfo{FileInfo: info, mode: e.mode, mask: e.mask}, nil
+}
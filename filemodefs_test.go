@@ -0,0 +1,124 @@
+package wrapfs_test
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/ironsmile/wrapfs"
+)
+
+// TestWithFileModeStat checks that the wrapped fs.FS implements fs.StatFS
+// and overrides only the permission bits, keeping the type bits of the
+// underlying file.
+func TestWithFileModeStat(t *testing.T) {
+	t.Parallel()
+
+	mode := fs.FileMode(0o755)
+	fileModeFS := wrapfs.WithFileMode(testFS, mode)
+
+	st, err := fs.Stat(fileModeFS, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.Stat returned an error: %s\n", err)
+	}
+
+	if actual := st.Mode(); actual != mode {
+		t.Errorf("expected mode %s but got %s", mode, actual)
+	}
+}
+
+// TestWithFileModePreservesTypeBits makes sure that WithFileMode does not
+// clobber the directory type bit when wrapping a directory's fs.FileInfo.
+func TestWithFileModePreservesTypeBits(t *testing.T) {
+	t.Parallel()
+
+	mode := fs.FileMode(0o644)
+	fileModeFS := wrapfs.WithFileMode(testFS, mode)
+
+	st, err := fs.Stat(fileModeFS, ".")
+	if err != nil {
+		t.Fatalf("fs.Stat returned an error: %s\n", err)
+	}
+
+	if !st.IsDir() {
+		t.Errorf("expected wrapped root entry to still be reported as a directory")
+	}
+
+	if actual := st.Mode().Perm(); actual != mode.Perm() {
+		t.Errorf("expected permission bits %s but got %s", mode.Perm(), actual)
+	}
+}
+
+// TestWithFileModeReadDir makes sure that using fs.ReadDir preserves the
+// overridden mode for dir entries.
+func TestWithFileModeReadDir(t *testing.T) {
+	t.Parallel()
+
+	mode := fs.FileMode(0o640)
+	fileModeFS := wrapfs.WithFileMode(testFS, mode)
+
+	entries, err := fs.ReadDir(fileModeFS, ".")
+	if err != nil {
+		t.Fatalf("fs.ReadDir error: %s", err)
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			t.Fatalf("[%s] entry.Info returned an error: %s\n", entry.Name(), err)
+		}
+
+		if actual := info.Mode().Perm(); actual != mode.Perm() {
+			t.Errorf("[%s] expected permission bits %s but got %s", entry.Name(), mode.Perm(), actual)
+		}
+	}
+}
+
+// TestWithFileModeOpenedFileStat checks that opened files return the
+// overridden mode when their Stat() method is called.
+func TestWithFileModeOpenedFileStat(t *testing.T) {
+	t.Parallel()
+
+	mode := fs.FileMode(0o600)
+	fileModeFS := wrapfs.WithFileMode(testFS, mode)
+
+	fh, err := fileModeFS.Open("modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.Open returned an error: %s\n", err)
+	}
+	defer fh.Close()
+
+	st, err := fh.Stat()
+	if err != nil {
+		t.Fatalf("File.Stat returned an error: %s\n", err)
+	}
+
+	if actual := st.Mode(); actual != mode {
+		t.Errorf("expected mode %s but got %s", mode, actual)
+	}
+}
+
+// TestWithFileModeMask checks that WithFileModeMask only overrides the bits
+// selected by the mask, leaving the rest of the underlying mode untouched.
+func TestWithFileModeMask(t *testing.T) {
+	t.Parallel()
+
+	underlying, err := fs.Stat(testFS, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.Stat returned an error: %s\n", err)
+	}
+
+	// Only override the "write" bits, leaving everything else as-is.
+	mask := fs.FileMode(0o222)
+	mode := fs.FileMode(0o222)
+	fileModeFS := wrapfs.WithFileModeMask(testFS, mode, mask)
+
+	st, err := fs.Stat(fileModeFS, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.Stat returned an error: %s\n", err)
+	}
+
+	expected := (underlying.Mode() &^ mask) | (mode & mask)
+	if actual := st.Mode(); actual != expected {
+		t.Errorf("expected mode %s but got %s", expected, actual)
+	}
+}
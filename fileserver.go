@@ -0,0 +1,80 @@
+package wrapfs
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// FileServer returns a drop-in replacement for http.FileServer(http.FS(fsys))
+// which additionally sets an ETag response header for files whose opened
+// fs.File implements ETager, such as one wrapped with WithETag or
+// WithContentHashETag, and honors If-None-Match by answering with
+// 304 Not Modified. This complements the If-Modified-Since handling that
+// http.FileServer already performs for files wrapped with WithModTime.
+func FileServer(fsys fs.FS) http.Handler {
+	inner := http.FileServer(http.FS(fsys))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			if etag, ok := fileETag(fsys, r.URL.Path); ok {
+				w.Header().Set("ETag", etag)
+
+				if ifNoneMatch := r.Header.Get("If-None-Match"); etagMatches(ifNoneMatch, etag) {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+			}
+		}
+
+		inner.ServeHTTP(w, r)
+	})
+}
+
+// fileETag opens the file served for urlPath and returns its ETag, if its
+// opened fs.File implements ETager.
+func fileETag(fsys fs.FS, urlPath string) (string, bool) {
+	name := strings.TrimPrefix(path.Clean(urlPath), "/")
+	if name == "" {
+		name = "."
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	tagger, ok := f.(ETager)
+	if !ok {
+		return "", false
+	}
+
+	etag := tagger.ETag()
+
+	return etag, etag != ""
+}
+
+// etagMatches reports whether etag satisfies the If-None-Match header value
+// ifNoneMatch, per RFC 9110 section 13.1.2.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	if ifNoneMatch == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		candidate = strings.TrimPrefix(candidate, "W/")
+
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
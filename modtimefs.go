@@ -0,0 +1,144 @@
+// Package wrapfs provides wrappers around fs.FS implementations which alter
+// or augment the metadata reported by the underlying file system, without
+// touching its actual contents.
+package wrapfs
+
+import (
+	"io/fs"
+	"path"
+	"time"
+)
+
+// WithModTime returns a fs.FS which wraps fsys and overrides the ModTime
+// reported by every file and directory in it with modTime. This is useful
+// for making fs.FS implementations which do not carry a meaningful
+// modification time, such as embed.FS, work with code which relies on it -
+// for example http.FileServer's support for If-Modified-Since.
+func WithModTime(fsys fs.FS, modTime time.Time) fs.FS {
+	return WithModTimeFunc(fsys, func(string, fs.FileInfo) time.Time {
+		return modTime
+	})
+}
+
+// WithModTimeMap returns a fs.FS which wraps fsys and overrides the ModTime
+// of every entry found in times, keyed by its slash-separated path from the
+// FS root. Entries not present in times report their original ModTime.
+func WithModTimeMap(fsys fs.FS, times map[string]time.Time) fs.FS {
+	return WithModTimeFunc(fsys, func(p string, info fs.FileInfo) time.Time {
+		if modTime, ok := times[p]; ok {
+			return modTime
+		}
+
+		return info.ModTime()
+	})
+}
+
+// WithModTimeFunc returns a fs.FS which wraps fsys and overrides the ModTime
+// reported by every file and directory in it with the value returned by fn.
+// fn is invoked lazily, from Stat, DirEntry.Info and File.Stat, with the
+// full slash-separated path of the entry from the FS root and the
+// fs.FileInfo as reported by the underlying fsys.
+func WithModTimeFunc(fsys fs.FS, fn func(path string, info fs.FileInfo) time.Time) fs.FS {
+	return &modTimeFS{
+		fsys: fsys,
+		fn:   fn,
+	}
+}
+
+type modTimeFS struct {
+	fsys fs.FS
+	fn   func(path string, info fs.FileInfo) time.Time
+}
+
+func (m *modTimeFS) Open(name string) (fs.File, error) {
+	f, err := m.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &modTimeFile{File: f, path: name, fn: m.fn}, nil
+}
+
+func (m *modTimeFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(m.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &modTimeFileInfo{FileInfo: info, modTime: m.fn(name, info)}, nil
+}
+
+func (m *modTimeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(m.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := make([]fs.DirEntry, len(entries))
+	for i, entry := range entries {
+		wrapped[i] = &modTimeDirEntry{DirEntry: entry, path: path.Join(name, entry.Name()), fn: m.fn}
+	}
+
+	return wrapped, nil
+}
+
+type modTimeFile struct {
+	fs.File
+	path string
+	fn   func(path string, info fs.FileInfo) time.Time
+}
+
+func (f *modTimeFile) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return &modTimeFileInfo{FileInfo: info, modTime: f.fn(f.path, info)}, nil
+}
+
+// ReadDir implements fs.ReadDirFile for directories opened through a
+// modTimeFS, so that entries read this way also report the overridden
+// modification time.
+func (f *modTimeFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	rd, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: f.path, Err: fs.ErrInvalid}
+	}
+
+	entries, err := rd.ReadDir(n)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := make([]fs.DirEntry, len(entries))
+	for i, entry := range entries {
+		wrapped[i] = &modTimeDirEntry{DirEntry: entry, path: path.Join(f.path, entry.Name()), fn: f.fn}
+	}
+
+	return wrapped, nil
+}
+
+type modTimeFileInfo struct {
+	fs.FileInfo
+	modTime time.Time
+}
+
+func (fi *modTimeFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+type modTimeDirEntry struct {
+	fs.DirEntry
+	path string
+	fn   func(path string, info fs.FileInfo) time.Time
+}
+
+func (e *modTimeDirEntry) Info() (fs.FileInfo, error) {
+	info, err := e.DirEntry.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	return &modTimeFileInfo{FileInfo: info, modTime: e.fn(e.path, info)}, nil
+}
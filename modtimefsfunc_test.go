@@ -0,0 +1,109 @@
+package wrapfs_test
+
+import (
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/wrapfs"
+)
+
+// TestWithModTimeFuncPath checks that WithModTimeFunc is invoked with the
+// full slash-separated path of the entry from the FS root.
+func TestWithModTimeFuncPath(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Unix(1727600261, 0)
+	var gotPath string
+
+	modTimeFS := wrapfs.WithModTimeFunc(testFS, func(p string, info fs.FileInfo) time.Time {
+		gotPath = p
+		return modTime
+	})
+
+	st, err := fs.Stat(modTimeFS, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.Stat returned an error: %s\n", err)
+	}
+
+	if gotPath != "modtimefs.go" {
+		t.Errorf("expected fn to be called with path %q but got %q", "modtimefs.go", gotPath)
+	}
+
+	if actual := st.ModTime(); actual != modTime {
+		t.Errorf("expected mod time %s but got %s", modTime, actual)
+	}
+}
+
+// TestWithModTimeFuncReadDir makes sure that fn is called with the joined
+// directory and entry name when listing a directory's contents.
+func TestWithModTimeFuncReadDir(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Unix(1727600261, 0)
+	var gotPaths []string
+
+	modTimeFS := wrapfs.WithModTimeFunc(testFS, func(p string, info fs.FileInfo) time.Time {
+		gotPaths = append(gotPaths, p)
+		return modTime
+	})
+
+	entries, err := fs.ReadDir(modTimeFS, ".")
+	if err != nil {
+		t.Fatalf("fs.ReadDir error: %s", err)
+	}
+
+	for _, entry := range entries {
+		if _, err := entry.Info(); err != nil {
+			t.Fatalf("[%s] entry.Info returned an error: %s\n", entry.Name(), err)
+		}
+	}
+
+	for _, p := range gotPaths {
+		if p != "modtimefs.go" {
+			t.Errorf("expected fn to be called with path %q but got %q", "modtimefs.go", p)
+		}
+	}
+}
+
+// TestWithModTimeMap checks that entries present in the map report the
+// configured mod time, while the rest fall back to their original one.
+func TestWithModTimeMap(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Unix(1727600261, 0)
+	modTimeFS := wrapfs.WithModTimeMap(testFS, map[string]time.Time{
+		"modtimefs.go": modTime,
+	})
+
+	st, err := fs.Stat(modTimeFS, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.Stat returned an error: %s\n", err)
+	}
+
+	if actual := st.ModTime(); actual != modTime {
+		t.Errorf("expected mod time %s but got %s", modTime, actual)
+	}
+}
+
+// TestWithModTimeMapFallback checks that an entry missing from the map
+// reports the underlying fs.FS's original mod time unchanged.
+func TestWithModTimeMapFallback(t *testing.T) {
+	t.Parallel()
+
+	original, err := fs.Stat(testFS, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.Stat returned an error: %s\n", err)
+	}
+
+	modTimeFS := wrapfs.WithModTimeMap(testFS, map[string]time.Time{})
+
+	st, err := fs.Stat(modTimeFS, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.Stat returned an error: %s\n", err)
+	}
+
+	if actual := st.ModTime(); actual != original.ModTime() {
+		t.Errorf("expected fallback mod time %s but got %s", original.ModTime(), actual)
+	}
+}
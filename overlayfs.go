@@ -0,0 +1,428 @@
+package wrapfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OverlayFS is a writable facade over an immutable base fs.FS, such as an
+// embed.FS. Writes, removals and time changes are kept in an in-memory
+// tier; reads fall through to the base for anything not shadowed there.
+// This is useful for hot-reloading a handful of assets while keeping the
+// rest of them embedded. The zero value is not usable - create one with
+// Overlay.
+type OverlayFS struct {
+	base fs.FS
+
+	mu       sync.RWMutex
+	files    map[string]*overlayFile
+	whiteout map[string]struct{}
+}
+
+// Overlay returns an *OverlayFS which layers a writable tier on top of
+// base. Until WriteFile, Remove or Chtimes are called, it behaves exactly
+// like base.
+func Overlay(base fs.FS) *OverlayFS {
+	return &OverlayFS{
+		base:     base,
+		files:    make(map[string]*overlayFile),
+		whiteout: make(map[string]struct{}),
+	}
+}
+
+type overlayFile struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// WriteFile stores data in the overlay's writable tier under name,
+// shadowing any entry with the same name in the base fs.FS.
+func (o *OverlayFS) WriteFile(name string, data []byte, mode fs.FileMode, modTime time.Time) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "writefile", Path: name, Err: fs.ErrInvalid}
+	}
+
+	cp := make([]byte, len(data))
+	copy(cp, data)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.files[name] = &overlayFile{data: cp, mode: mode, modTime: modTime}
+	delete(o.whiteout, name)
+
+	return nil
+}
+
+// Remove deletes name from the overlay. If name exists in the base fs.FS,
+// whether or not it is also shadowed by the writable tier, it is recorded
+// as a whiteout so that it disappears from Stat, Open and ReadDir even
+// though the base is left untouched.
+func (o *OverlayFS) Remove(name string) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.files[name]; ok {
+		delete(o.files, name)
+		if _, err := fs.Stat(o.base, name); err == nil {
+			o.whiteout[name] = struct{}{}
+		}
+		return nil
+	}
+
+	if _, err := fs.Stat(o.base, name); err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	o.whiteout[name] = struct{}{}
+
+	return nil
+}
+
+// Chtimes changes the modification time reported for name to mtime. atime
+// is accepted for symmetry with os.Chtimes but is not tracked, since
+// fs.FileInfo has no way to report it. If name is only present in the base
+// fs.FS, it is copied into the writable tier so that the new mtime can be
+// recorded.
+func (o *OverlayFS) Chtimes(name string, atime, mtime time.Time) error {
+	_ = atime
+
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrInvalid}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if f, ok := o.files[name]; ok {
+		f.modTime = mtime
+		return nil
+	}
+
+	info, err := fs.Stat(o.base, name)
+	if err != nil {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if info.IsDir() {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrInvalid}
+	}
+
+	data, err := fs.ReadFile(o.base, name)
+	if err != nil {
+		return err
+	}
+
+	o.files[name] = &overlayFile{data: data, mode: info.Mode(), modTime: mtime}
+
+	return nil
+}
+
+// Open implements fs.FS.
+func (o *OverlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	o.mu.RLock()
+
+	if _, ok := o.whiteout[name]; ok {
+		o.mu.RUnlock()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if f, ok := o.files[name]; ok {
+		info := &overlayFileInfo{name: path.Base(name), size: int64(len(f.data)), mode: f.mode, modTime: f.modTime}
+		data := f.data
+		o.mu.RUnlock()
+		return &overlayOpenFile{Reader: bytes.NewReader(data), info: info}, nil
+	}
+	o.mu.RUnlock()
+
+	f, err := o.base.Open(name)
+	if err == nil {
+		info, statErr := f.Stat()
+		if statErr != nil {
+			f.Close()
+			return nil, statErr
+		}
+
+		if info.IsDir() {
+			return &overlayDirFile{under: f, ofs: o, path: name}, nil
+		}
+
+		return f, nil
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		o.mu.RLock()
+		hasDir := o.hasOverlayDirLocked(name)
+		o.mu.RUnlock()
+
+		if hasDir {
+			info := &overlayFileInfo{name: path.Base(name), mode: fs.ModeDir | 0o755}
+			return &overlayDirFile{ofs: o, path: name, info: info}, nil
+		}
+	}
+
+	return nil, err
+}
+
+// Stat implements fs.StatFS.
+func (o *OverlayFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if _, ok := o.whiteout[name]; ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if f, ok := o.files[name]; ok {
+		return &overlayFileInfo{name: path.Base(name), size: int64(len(f.data)), mode: f.mode, modTime: f.modTime}, nil
+	}
+
+	info, err := fs.Stat(o.base, name)
+	if err == nil {
+		return info, nil
+	}
+
+	if errors.Is(err, fs.ErrNotExist) && o.hasOverlayDirLocked(name) {
+		return &overlayFileInfo{name: path.Base(name), mode: fs.ModeDir | 0o755}, nil
+	}
+
+	return nil, err
+}
+
+// hasOverlayDirLocked reports whether name is an intermediate directory
+// implied by the path of a file written to the writable tier, such as
+// "sub" after WriteFile("sub/dir/new.txt", ...). Callers must hold o.mu.
+func (o *OverlayFS) hasOverlayDirLocked(name string) bool {
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+
+	for p := range o.files {
+		if p != name && strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReadDir implements fs.ReadDirFS, merging the writable tier's entries
+// with the base fs.FS's, minus anything whited out. Writable-tier files
+// nested under subdirectories which do not exist in the base fs.FS
+// synthesize the missing intermediate directory entries, so that the
+// overlay as a whole stays walkable with fs.WalkDir.
+func (o *OverlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	byName := make(map[string]fs.DirEntry)
+
+	baseEntries, baseErr := fs.ReadDir(o.base, name)
+	if baseErr != nil && !errors.Is(baseErr, fs.ErrNotExist) {
+		return nil, baseErr
+	}
+
+	for _, e := range baseEntries {
+		p := path.Join(name, e.Name())
+		if _, ok := o.whiteout[p]; ok {
+			continue
+		}
+		byName[e.Name()] = e
+	}
+
+	hasOverlayEntries := false
+	for p, f := range o.files {
+		rel, ok := relativeTo(name, p)
+		if !ok {
+			continue
+		}
+
+		hasOverlayEntries = true
+
+		child, isNested := cutFirstSegment(rel)
+		if !isNested {
+			info := &overlayFileInfo{name: child, size: int64(len(f.data)), mode: f.mode, modTime: f.modTime}
+			byName[child] = &overlayDirEntry{info: info}
+			continue
+		}
+
+		if _, exists := byName[child]; !exists {
+			info := &overlayFileInfo{name: child, mode: fs.ModeDir | 0o755, modTime: f.modTime}
+			byName[child] = &overlayDirEntry{info: info}
+		}
+	}
+
+	if baseErr != nil && !hasOverlayEntries {
+		return nil, baseErr
+	}
+
+	entries := make([]fs.DirEntry, 0, len(byName))
+	for _, e := range byName {
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	return entries, nil
+}
+
+// relativeTo reports the path of p relative to dir, when p lies within
+// dir's subtree.
+func relativeTo(dir, p string) (rel string, ok bool) {
+	if dir == "." {
+		return p, true
+	}
+
+	prefix := dir + "/"
+	if !strings.HasPrefix(p, prefix) {
+		return "", false
+	}
+
+	return p[len(prefix):], true
+}
+
+// cutFirstSegment splits a slash-separated relative path into its first
+// segment, reporting whether further segments follow it.
+func cutFirstSegment(rel string) (segment string, nested bool) {
+	if i := strings.IndexByte(rel, '/'); i >= 0 {
+		return rel[:i], true
+	}
+
+	return rel, false
+}
+
+// overlayFileInfo is the fs.FileInfo for an entry created in the writable
+// tier through WriteFile or Chtimes.
+type overlayFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (fi *overlayFileInfo) Name() string       { return fi.name }
+func (fi *overlayFileInfo) Size() int64        { return fi.size }
+func (fi *overlayFileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi *overlayFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *overlayFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *overlayFileInfo) Sys() any           { return nil }
+
+// overlayOpenFile is the fs.File returned for an entry stored in the
+// writable tier.
+type overlayOpenFile struct {
+	*bytes.Reader
+	info *overlayFileInfo
+}
+
+func (f *overlayOpenFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *overlayOpenFile) Close() error               { return nil }
+
+// overlayDirEntry is the fs.DirEntry for an entry created in the writable
+// tier, returned from ReadDir.
+type overlayDirEntry struct {
+	info *overlayFileInfo
+}
+
+func (e *overlayDirEntry) Name() string               { return e.info.name }
+func (e *overlayDirEntry) IsDir() bool                { return e.info.IsDir() }
+func (e *overlayDirEntry) Type() fs.FileMode          { return e.info.mode.Type() }
+func (e *overlayDirEntry) Info() (fs.FileInfo, error) { return e.info, nil }
+
+// overlayDirFile is the fs.File returned for a directory opened through an
+// OverlayFS, so that its ReadDir goes through OverlayFS.ReadDir instead of
+// the base's, picking up writable-tier entries and whiteouts. It fetches
+// the merged listing once and then pages through it, as fs.ReadDirFile
+// requires.
+//
+// under is the directory as opened from the base fs.FS, or nil when the
+// directory only exists as an intermediate path implied by a nested
+// writable-tier file, in which case info is used to answer Stat instead.
+type overlayDirFile struct {
+	under fs.File
+	ofs   *OverlayFS
+	path  string
+	info  *overlayFileInfo
+
+	mu      sync.Mutex
+	loaded  bool
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *overlayDirFile) Stat() (fs.FileInfo, error) {
+	if d.under != nil {
+		return d.under.Stat()
+	}
+
+	return d.info, nil
+}
+
+func (d *overlayDirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: fs.ErrInvalid}
+}
+
+func (d *overlayDirFile) Close() error {
+	if d.under != nil {
+		return d.under.Close()
+	}
+
+	return nil
+}
+
+func (d *overlayDirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.loaded {
+		entries, err := d.ofs.ReadDir(d.path)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.loaded = true
+	}
+
+	remaining := d.entries[d.offset:]
+
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+
+	d.offset += n
+
+	return remaining[:n], nil
+}
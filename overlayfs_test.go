@@ -0,0 +1,285 @@
+package wrapfs_test
+
+import (
+	"io"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/ironsmile/wrapfs"
+)
+
+// TestOverlayReadsThroughToBase checks that a file not shadowed in the
+// overlay is read straight from the base fs.FS.
+func TestOverlayReadsThroughToBase(t *testing.T) {
+	t.Parallel()
+
+	overlay := wrapfs.Overlay(testFS)
+
+	data, err := fs.ReadFile(overlay, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.ReadFile returned an error: %s\n", err)
+	}
+
+	expected, err := fs.ReadFile(testFS, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.ReadFile on base returned an error: %s\n", err)
+	}
+
+	if string(data) != string(expected) {
+		t.Errorf("expected overlay to read through to the base fs.FS unchanged")
+	}
+}
+
+// TestOverlayWriteFileShadowsBase checks that WriteFile makes subsequent
+// reads see the overlay's content instead of the base's.
+func TestOverlayWriteFileShadowsBase(t *testing.T) {
+	t.Parallel()
+
+	overlay := wrapfs.Overlay(testFS)
+	modTime := time.Unix(1727600261, 0)
+
+	if err := overlay.WriteFile("modtimefs.go", []byte("shadowed"), 0o644, modTime); err != nil {
+		t.Fatalf("WriteFile returned an error: %s\n", err)
+	}
+
+	data, err := fs.ReadFile(overlay, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.ReadFile returned an error: %s\n", err)
+	}
+
+	if string(data) != "shadowed" {
+		t.Errorf("expected shadowed content, got %q", data)
+	}
+
+	st, err := fs.Stat(overlay, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.Stat returned an error: %s\n", err)
+	}
+
+	if !st.ModTime().Equal(modTime) {
+		t.Errorf("expected mod time %s but got %s", modTime, st.ModTime())
+	}
+}
+
+// TestOverlayWriteFileNewEntry checks that a new file created in the
+// overlay appears in ReadDir even though it does not exist in the base.
+func TestOverlayWriteFileNewEntry(t *testing.T) {
+	t.Parallel()
+
+	overlay := wrapfs.Overlay(testFS)
+
+	if err := overlay.WriteFile("new.txt", []byte("hi"), 0o644, time.Time{}); err != nil {
+		t.Fatalf("WriteFile returned an error: %s\n", err)
+	}
+
+	entries, err := fs.ReadDir(overlay, ".")
+	if err != nil {
+		t.Fatalf("fs.ReadDir returned an error: %s\n", err)
+	}
+
+	found := false
+	for _, e := range entries {
+		if e.Name() == "new.txt" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("expected new.txt to show up in ReadDir")
+	}
+}
+
+// TestOverlayRemoveWhitesOutBaseEntry checks that removing a base-only
+// entry hides it from Stat and ReadDir without touching the base.
+func TestOverlayRemoveWhitesOutBaseEntry(t *testing.T) {
+	t.Parallel()
+
+	overlay := wrapfs.Overlay(testFS)
+
+	if err := overlay.Remove("modtimefs.go"); err != nil {
+		t.Fatalf("Remove returned an error: %s\n", err)
+	}
+
+	if _, err := fs.Stat(overlay, "modtimefs.go"); err == nil {
+		t.Fatalf("expected fs.Stat to fail for a removed entry")
+	}
+
+	entries, err := fs.ReadDir(overlay, ".")
+	if err != nil {
+		t.Fatalf("fs.ReadDir returned an error: %s\n", err)
+	}
+
+	for _, e := range entries {
+		if e.Name() == "modtimefs.go" {
+			t.Errorf("expected modtimefs.go to be hidden from ReadDir after Remove")
+		}
+	}
+
+	if _, err := fs.Stat(testFS, "modtimefs.go"); err != nil {
+		t.Errorf("expected the base fs.FS to be unaffected by Remove: %s", err)
+	}
+}
+
+// TestOverlayChtimes checks that Chtimes updates the reported mod time for
+// an entry that only existed in the base fs.FS.
+func TestOverlayChtimes(t *testing.T) {
+	t.Parallel()
+
+	overlay := wrapfs.Overlay(testFS)
+	mtime := time.Unix(1700000000, 0)
+
+	if err := overlay.Chtimes("modtimefs.go", time.Time{}, mtime); err != nil {
+		t.Fatalf("Chtimes returned an error: %s\n", err)
+	}
+
+	st, err := fs.Stat(overlay, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.Stat returned an error: %s\n", err)
+	}
+
+	if !st.ModTime().Equal(mtime) {
+		t.Errorf("expected mod time %s but got %s", mtime, st.ModTime())
+	}
+
+	data, err := fs.ReadFile(overlay, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.ReadFile returned an error: %s\n", err)
+	}
+
+	expected, err := fs.ReadFile(testFS, "modtimefs.go")
+	if err != nil {
+		t.Fatalf("fs.ReadFile on base returned an error: %s\n", err)
+	}
+
+	if string(data) != string(expected) {
+		t.Errorf("expected Chtimes to leave the file content unchanged")
+	}
+}
+
+// TestOverlayDirFileReadDirPages checks that repeated ReadDir(n) calls on
+// the same opened directory page through the merged listing instead of
+// returning the same entries over and over, and terminate with io.EOF - the
+// contract http.FileServer's directory listing relies on.
+func TestOverlayDirFileReadDirPages(t *testing.T) {
+	t.Parallel()
+
+	overlay := wrapfs.Overlay(testFS)
+
+	if err := overlay.WriteFile("new.txt", []byte("hi"), 0o644, time.Time{}); err != nil {
+		t.Fatalf("WriteFile returned an error: %s\n", err)
+	}
+
+	all, err := fs.ReadDir(overlay, ".")
+	if err != nil {
+		t.Fatalf("fs.ReadDir returned an error: %s\n", err)
+	}
+
+	fh, err := overlay.Open(".")
+	if err != nil {
+		t.Fatalf("Open returned an error: %s\n", err)
+	}
+	defer fh.Close()
+
+	rd, ok := fh.(interface {
+		ReadDir(n int) ([]fs.DirEntry, error)
+	})
+	if !ok {
+		t.Fatalf("opened dir does not implement fs.ReadDirFile")
+	}
+
+	seen := make(map[string]bool)
+	for {
+		entries, err := rd.ReadDir(1)
+		if len(entries) == 0 {
+			if err != io.EOF {
+				t.Fatalf("expected io.EOF once entries are exhausted, got %v", err)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadDir returned an error: %s\n", err)
+		}
+
+		for _, e := range entries {
+			if seen[e.Name()] {
+				t.Fatalf("ReadDir(1) returned %q more than once instead of paging", e.Name())
+			}
+			seen[e.Name()] = true
+		}
+	}
+
+	if len(seen) != len(all) {
+		t.Errorf("expected to page through %d entries but saw %d", len(all), len(seen))
+	}
+}
+
+// TestOverlayWriteFileNestedPathIsWalkable checks that a file written under
+// a subdirectory which does not exist in the base fs.FS gets synthesized
+// intermediate directory entries, so that it shows up in ReadDir and is
+// visited by fs.WalkDir - not just directly Open/ReadFile-able.
+func TestOverlayWriteFileNestedPathIsWalkable(t *testing.T) {
+	t.Parallel()
+
+	overlay := wrapfs.Overlay(testFS)
+
+	if err := overlay.WriteFile("newdir/new.txt", []byte("hi"), 0o644, time.Time{}); err != nil {
+		t.Fatalf("WriteFile returned an error: %s\n", err)
+	}
+
+	rootEntries, err := fs.ReadDir(overlay, ".")
+	if err != nil {
+		t.Fatalf("fs.ReadDir returned an error: %s\n", err)
+	}
+
+	found := false
+	for _, e := range rootEntries {
+		if e.Name() == "newdir" {
+			found = true
+			if !e.IsDir() {
+				t.Errorf("expected newdir to be reported as a directory")
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected newdir to show up in the root ReadDir")
+	}
+
+	st, err := fs.Stat(overlay, "newdir")
+	if err != nil {
+		t.Fatalf("fs.Stat(\"newdir\") returned an error: %s\n", err)
+	}
+	if !st.IsDir() {
+		t.Errorf("expected fs.Stat(\"newdir\") to report a directory")
+	}
+
+	var visited []string
+	if err := fs.WalkDir(overlay, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		visited = append(visited, p)
+		return nil
+	}); err != nil {
+		t.Fatalf("fs.WalkDir returned an error: %s\n", err)
+	}
+
+	want := "newdir/new.txt"
+	seen := false
+	for _, p := range visited {
+		if p == want {
+			seen = true
+		}
+	}
+	if !seen {
+		t.Errorf("expected fs.WalkDir to visit %q, visited %v", want, visited)
+	}
+
+	data, err := fs.ReadFile(overlay, "newdir/new.txt")
+	if err != nil {
+		t.Fatalf("fs.ReadFile returned an error: %s\n", err)
+	}
+	if string(data) != "hi" {
+		t.Errorf("expected content %q but got %q", "hi", data)
+	}
+}
@@ -0,0 +1,332 @@
+package wrapfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// precompressedExtensions maps a Content-Encoding token to the file
+// extension its precompressed sibling is expected to carry.
+var precompressedExtensions = map[string]string{
+	"gzip": ".gz",
+	"br":   ".br",
+}
+
+// PrecompressedFS is implemented by the fs.FS returned from
+// WithPrecompressed. PrecompressedFileServer uses it to serve whichever
+// precompressed sibling the request's Accept-Encoding header allows.
+type PrecompressedFS interface {
+	fs.FS
+
+	// Encodings returns the encodings WithPrecompressed was configured
+	// with, in preference order.
+	Encodings() []string
+
+	// OpenVariant opens the sibling of name carrying the given encoding,
+	// such as "foo.html.gz" for name "foo.html" and encoding "gzip". ok is
+	// false if encoding is not one WithPrecompressed was configured with,
+	// or if no such sibling exists.
+	OpenVariant(name, encoding string) (f fs.File, ok bool, err error)
+}
+
+// WithPrecompressed returns a fs.FS which wraps fsys and hides the
+// precompressed siblings of every file from Open, Stat and ReadDir - for
+// example "foo.html.gz" and "foo.html.br" disappear once "gzip" and "br"
+// are passed as encodings, leaving only the logical "foo.html". The
+// siblings remain reachable through OpenVariant, which
+// PrecompressedFileServer uses to transparently serve them.
+func WithPrecompressed(fsys fs.FS, encodings ...string) fs.FS {
+	variants := make([]precompressedVariant, 0, len(encodings))
+	for _, encoding := range encodings {
+		ext, ok := precompressedExtensions[encoding]
+		if !ok {
+			continue
+		}
+
+		variants = append(variants, precompressedVariant{encoding: encoding, ext: ext})
+	}
+
+	return &precompressedFS{fsys: fsys, variants: variants}
+}
+
+type precompressedVariant struct {
+	encoding string
+	ext      string
+}
+
+type precompressedFS struct {
+	fsys     fs.FS
+	variants []precompressedVariant
+}
+
+func (p *precompressedFS) isVariant(name string) bool {
+	for _, v := range p.variants {
+		if strings.HasSuffix(name, v.ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *precompressedFS) Open(name string) (fs.File, error) {
+	if p.isVariant(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	f, err := p.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return &precompressedDirFile{File: f, pfs: p}, nil
+	}
+
+	return f, nil
+}
+
+// precompressedDirFile wraps a directory opened from the underlying fs.FS
+// so that its ReadDir also hides precompressed siblings - net/http's
+// directory listing calls ReadDir on the opened file directly, not on the
+// FS-level ReadDir method.
+type precompressedDirFile struct {
+	fs.File
+	pfs *precompressedFS
+}
+
+func (d *precompressedDirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	rd, ok := d.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: "", Err: fs.ErrInvalid}
+	}
+
+	entries, err := rd.ReadDir(n)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		if d.pfs.isVariant(e.Name()) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+
+	return filtered, nil
+}
+
+func (p *precompressedFS) Stat(name string) (fs.FileInfo, error) {
+	if p.isVariant(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return fs.Stat(p.fsys, name)
+}
+
+func (p *precompressedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(p.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		if p.isVariant(e.Name()) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+
+	return filtered, nil
+}
+
+func (p *precompressedFS) Encodings() []string {
+	encodings := make([]string, len(p.variants))
+	for i, v := range p.variants {
+		encodings[i] = v.encoding
+	}
+
+	return encodings
+}
+
+func (p *precompressedFS) OpenVariant(name, encoding string) (fs.File, bool, error) {
+	ext := ""
+	for _, v := range p.variants {
+		if v.encoding == encoding {
+			ext = v.ext
+			break
+		}
+	}
+	if ext == "" {
+		return nil, false, nil
+	}
+
+	vf, err := p.fsys.Open(name + ext)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	variantInfo, err := vf.Stat()
+	if err != nil {
+		vf.Close()
+		return nil, false, err
+	}
+
+	originalInfo, err := fs.Stat(p.fsys, name)
+	if err != nil {
+		vf.Close()
+		return nil, false, err
+	}
+
+	return &precompressedFile{
+		File:    vf,
+		name:    path.Base(name),
+		size:    variantInfo.Size(),
+		modTime: originalInfo.ModTime(),
+		mode:    originalInfo.Mode(),
+	}, true, nil
+}
+
+// precompressedFile presents a precompressed variant's content under the
+// logical, uncompressed file's name and ModTime, while reporting the
+// variant's own Size.
+type precompressedFile struct {
+	fs.File
+	name    string
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+}
+
+func (f *precompressedFile) Stat() (fs.FileInfo, error) {
+	return f, nil
+}
+
+func (f *precompressedFile) Name() string       { return f.name }
+func (f *precompressedFile) Size() int64        { return f.size }
+func (f *precompressedFile) Mode() fs.FileMode  { return f.mode }
+func (f *precompressedFile) ModTime() time.Time { return f.modTime }
+func (f *precompressedFile) IsDir() bool        { return false }
+func (f *precompressedFile) Sys() any           { return nil }
+
+// Seek forwards to the underlying variant file if it supports seeking.
+// fs.File does not require Seek, so it is implemented here explicitly
+// rather than relying on the embedded fs.File to promote it - embedding
+// only promotes methods declared on fs.File itself.
+func (f *precompressedFile) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := f.File.(io.Seeker)
+	if !ok {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	return seeker.Seek(offset, whence)
+}
+
+// PrecompressedFileServer returns a drop-in replacement for
+// http.FileServer(http.FS(fsys)) which, when fsys is a PrecompressedFS
+// (one returned by WithPrecompressed), transparently serves whichever
+// precompressed variant the request's Accept-Encoding header accepts,
+// highest-preference first, setting Content-Encoding and Vary accordingly.
+// ModTime-based conditional requests keep working end-to-end because the
+// variant is served under the logical file's ModTime.
+func PrecompressedFileServer(fsys fs.FS) http.Handler {
+	inner := http.FileServer(http.FS(fsys))
+
+	pfs, ok := fsys.(PrecompressedFS)
+	if !ok {
+		return inner
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			inner.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if name == "" {
+			name = "."
+		}
+
+		acceptEncoding := r.Header.Get("Accept-Encoding")
+
+		for _, encoding := range pfs.Encodings() {
+			if !acceptsEncoding(acceptEncoding, encoding) {
+				continue
+			}
+
+			f, ok, err := pfs.OpenVariant(name, encoding)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				continue
+			}
+			defer f.Close()
+
+			seeker, ok := f.(io.ReadSeeker)
+			if !ok {
+				http.Error(w, "precompressed variant is not seekable", http.StatusInternalServerError)
+				return
+			}
+
+			info, err := f.Stat()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Encoding", encoding)
+			http.ServeContent(w, r, info.Name(), info.ModTime(), seeker)
+			return
+		}
+
+		inner.ServeHTTP(w, r)
+	})
+}
+
+// acceptsEncoding reports whether acceptEncoding, the value of an
+// Accept-Encoding request header, lists encoding as acceptable.
+func acceptsEncoding(acceptEncoding, encoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+
+		// A quality value of zero marks the encoding as not acceptable.
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(param, "=")
+			if !strings.EqualFold(strings.TrimSpace(key), "q") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q == 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
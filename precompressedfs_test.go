@@ -0,0 +1,157 @@
+package wrapfs_test
+
+import (
+	"embed"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ironsmile/wrapfs"
+)
+
+//go:embed testdata/precompressed
+var precompressedTestData embed.FS
+
+func precompressedFixture(t *testing.T) fs.FS {
+	t.Helper()
+
+	sub, err := fs.Sub(precompressedTestData, "testdata/precompressed")
+	if err != nil {
+		t.Fatalf("fs.Sub returned an error: %s\n", err)
+	}
+
+	return sub
+}
+
+// TestWithPrecompressedHidesVariants checks that the .gz and .br siblings
+// are hidden from ReadDir, leaving only the logical file.
+func TestWithPrecompressedHidesVariants(t *testing.T) {
+	t.Parallel()
+
+	fsys := wrapfs.WithPrecompressed(precompressedFixture(t), "gzip", "br")
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatalf("fs.ReadDir returned an error: %s\n", err)
+	}
+
+	if len(entries) != 1 || entries[0].Name() != "foo.html" {
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.Name()
+		}
+		t.Fatalf("expected only [foo.html] but got %v", names)
+	}
+}
+
+// TestWithPrecompressedHidesVariantsFromOpenedDirReadDir checks that the
+// .gz/.br siblings are also hidden when ReadDir is called on the handle
+// returned by Open, not just through fs.ReadDir(fsys, ...) - the path
+// net/http's directory listing actually uses.
+func TestWithPrecompressedHidesVariantsFromOpenedDirReadDir(t *testing.T) {
+	t.Parallel()
+
+	fsys := wrapfs.WithPrecompressed(precompressedFixture(t), "gzip", "br")
+
+	fh, err := fsys.Open(".")
+	if err != nil {
+		t.Fatalf("Open returned an error: %s\n", err)
+	}
+	defer fh.Close()
+
+	rd, ok := fh.(fs.ReadDirFile)
+	if !ok {
+		t.Fatalf("opened dir does not implement fs.ReadDirFile")
+	}
+
+	entries, err := rd.ReadDir(-1)
+	if err != nil {
+		t.Fatalf("ReadDir returned an error: %s\n", err)
+	}
+
+	if len(entries) != 1 || entries[0].Name() != "foo.html" {
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.Name()
+		}
+		t.Fatalf("expected only [foo.html] but got %v", names)
+	}
+}
+
+// TestWithPrecompressedHidesVariantsFromOpen checks that opening a
+// precompressed sibling directly reports it as not existing.
+func TestWithPrecompressedHidesVariantsFromOpen(t *testing.T) {
+	t.Parallel()
+
+	fsys := wrapfs.WithPrecompressed(precompressedFixture(t), "gzip")
+
+	if _, err := fsys.Open("foo.html.gz"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected foo.html.gz to be hidden, got err=%v", err)
+	}
+}
+
+// TestPrecompressedFileServerServesGzip checks that the handler serves the
+// gzip sibling and sets Content-Encoding when the client accepts it.
+func TestPrecompressedFileServerServesGzip(t *testing.T) {
+	t.Parallel()
+
+	fsys := wrapfs.WithPrecompressed(precompressedFixture(t), "gzip", "br")
+	handler := wrapfs.PrecompressedFileServer(fsys)
+
+	req := httptest.NewRequest(http.MethodGet, "/foo.html", nil)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+	result := resp.Result()
+	defer result.Body.Close()
+
+	if got := result.Header.Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("expected Content-Encoding gzip but got %q", got)
+	}
+
+	if got := result.Header.Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("expected Vary Accept-Encoding but got %q", got)
+	}
+
+	body, err := io.ReadAll(result.Body)
+	if err != nil {
+		t.Fatalf("reading body returned an error: %s\n", err)
+	}
+
+	if string(body) != "gzip-bytes\n" {
+		t.Errorf("expected gzip variant content, got %q", body)
+	}
+}
+
+// TestPrecompressedFileServerFallback checks that the handler serves the
+// original file when the client does not accept any known encoding.
+func TestPrecompressedFileServerFallback(t *testing.T) {
+	t.Parallel()
+
+	fsys := wrapfs.WithPrecompressed(precompressedFixture(t), "gzip", "br")
+	handler := wrapfs.PrecompressedFileServer(fsys)
+
+	req := httptest.NewRequest(http.MethodGet, "/foo.html", nil)
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+	result := resp.Result()
+	defer result.Body.Close()
+
+	if got := result.Header.Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no Content-Encoding but got %q", got)
+	}
+
+	body, err := io.ReadAll(result.Body)
+	if err != nil {
+		t.Fatalf("reading body returned an error: %s\n", err)
+	}
+
+	if string(body) != "<p>plain</p>\n" {
+		t.Errorf("expected original content, got %q", body)
+	}
+}